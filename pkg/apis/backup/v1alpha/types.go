@@ -0,0 +1,136 @@
+//
+// DISCLAIMER
+//
+// Copyright 2018 ArangoDB GmbH, Cologne, Germany
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Copyright holder is ArangoDB GmbH, Cologne, Germany
+//
+
+package v1alpha
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ArangoBackup is the schema for the ArangoBackup API, describing a single
+// hot-backup of an ArangoDeployment.
+type ArangoBackup struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ArangoBackupSpec   `json:"spec"`
+	Status ArangoBackupStatus `json:"status"`
+}
+
+// ArangoBackupSpec holds the desired state of an ArangoBackup.
+type ArangoBackupSpec struct {
+	// Deployment is the name of the ArangoDeployment to back up. It must
+	// exist in the same namespace as this ArangoBackup.
+	Deployment string `json:"deployment"`
+	// Upload, when set, causes the backup to be uploaded to the repository
+	// it configures right after it is created.
+	Upload *BackupUploadSpec `json:"upload,omitempty"`
+	// Retention configures when older backups created from the same
+	// schedule are cleaned up.
+	Retention *BackupRetentionSpec `json:"retention,omitempty"`
+	// Schedule is a standard 5-field cron expression. When set, this
+	// ArangoBackup acts as a template that the backup controller uses to
+	// create a new ArangoBackup on every tick.
+	Schedule string `json:"schedule,omitempty"`
+}
+
+// BackupUploadSpec configures uploading a backup to remote storage through
+// ArangoDB's `/_admin/backup/upload` endpoint.
+type BackupUploadSpec struct {
+	// RepositoryURL is the rclone-style repository URL, e.g.
+	// "s3://bucket/path" or "gs://bucket/path".
+	RepositoryURL string `json:"repositoryURL"`
+	// CredentialsSecretName is the name of a Secret in the same namespace
+	// holding the credentials for RepositoryURL.
+	CredentialsSecretName string `json:"credentialsSecretName,omitempty"`
+}
+
+// BackupRetentionSpec configures how many backups created from the same
+// schedule are kept.
+type BackupRetentionSpec struct {
+	// KeepLast, when > 0, keeps only the most recent KeepLast backups.
+	KeepLast int `json:"keepLast,omitempty"`
+	// KeepFor, when set, removes backups older than this duration
+	// (e.g. "168h" for a week).
+	KeepFor string `json:"keepFor,omitempty"`
+}
+
+// ArangoBackupConditionType is a valid value for ArangoBackupCondition.Type.
+type ArangoBackupConditionType string
+
+const (
+	// ArangoBackupConditionAvailable indicates the backup was created
+	// successfully and can be restored from.
+	ArangoBackupConditionAvailable ArangoBackupConditionType = "Available"
+	// ArangoBackupConditionUploaded indicates the backup was uploaded to
+	// its configured BackupUploadSpec.RepositoryURL.
+	ArangoBackupConditionUploaded ArangoBackupConditionType = "Uploaded"
+	// ArangoBackupConditionFailed indicates that creating, uploading or
+	// scheduling the backup returned an error. The controller retries on a
+	// later reconcile and does not clear this condition on success.
+	ArangoBackupConditionFailed ArangoBackupConditionType = "Failed"
+)
+
+// ArangoBackupCondition represents the state of one aspect of an
+// ArangoBackup, following the conventions already used for ArangoDeployment
+// conditions.
+type ArangoBackupCondition struct {
+	Type               ArangoBackupConditionType `json:"type"`
+	Status             metav1.ConditionStatus    `json:"status"`
+	LastTransitionTime metav1.Time               `json:"lastTransitionTime,omitempty"`
+	Reason             string                    `json:"reason,omitempty"`
+	Message            string                    `json:"message,omitempty"`
+}
+
+// ArangoBackupStatus holds the observed state of an ArangoBackup.
+type ArangoBackupStatus struct {
+	// BackupID is the ID assigned by ArangoDB's backup API.
+	BackupID string `json:"backupID,omitempty"`
+	// CreatedAt is when the controller recorded the backup as created,
+	// taken from the operator's clock.
+	CreatedAt *metav1.Time `json:"createdAt,omitempty"`
+	// SizeInBytes is the on-disk size of the backup, as reported by
+	// ArangoDB.
+	SizeInBytes int64 `json:"sizeInBytes,omitempty"`
+	// Conditions is the list of conditions observed for this backup.
+	Conditions []ArangoBackupCondition `json:"conditions,omitempty"`
+	// LastScheduleTime is when this ArangoBackup, acting as a Schedule
+	// template, last created a new ArangoBackup from itself. Only set on
+	// templates.
+	LastScheduleTime *metav1.Time `json:"lastScheduleTime,omitempty"`
+	// ScheduledFrom is the name of the ArangoBackup template this backup
+	// was created from, if any. Its presence marks this ArangoBackup as a
+	// spawned instance rather than a Schedule template, so the controller
+	// does not try to schedule further instances from it.
+	ScheduledFrom string `json:"scheduledFrom,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ArangoBackupList is a list of ArangoBackup resources.
+type ArangoBackupList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []ArangoBackup `json:"items"`
+}
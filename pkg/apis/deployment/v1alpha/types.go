@@ -0,0 +1,259 @@
+//
+// DISCLAIMER
+//
+// Copyright 2018 ArangoDB GmbH, Cologne, Germany
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Copyright holder is ArangoDB GmbH, Cologne, Germany
+//
+
+package v1alpha
+
+import (
+	core "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ArangoDeployment is the schema for the ArangoDeployment API, describing a
+// single ArangoDB cluster managed by the operator.
+type ArangoDeployment struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   DeploymentSpec   `json:"spec"`
+	Status DeploymentStatus `json:"status"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ArangoDeploymentList is a list of ArangoDeployment resources.
+type ArangoDeploymentList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []ArangoDeployment `json:"items"`
+}
+
+// ServerGroup identifies one of the server groups that make up an
+// ArangoDeployment.
+type ServerGroup string
+
+const (
+	// ServerGroupAgents identifies the agency members.
+	ServerGroupAgents ServerGroup = "agents"
+	// ServerGroupDBServers identifies the dbserver members.
+	ServerGroupDBServers ServerGroup = "dbservers"
+	// ServerGroupCoordinators identifies the coordinator members.
+	ServerGroupCoordinators ServerGroup = "coordinators"
+)
+
+// AsRole returns the human-readable role name for the group, as used in
+// log fields and Kubernetes Events.
+func (g ServerGroup) AsRole() string {
+	return string(g)
+}
+
+// DeploymentSpec holds the desired state of an ArangoDeployment.
+type DeploymentSpec struct {
+	Agents       ServerGroupSpec `json:"agents,omitempty"`
+	DBServers    ServerGroupSpec `json:"dbservers,omitempty"`
+	Coordinators ServerGroupSpec `json:"coordinators,omitempty"`
+	// Restore, when set, triggers a one-time restore of the cluster from
+	// the named ArangoBackup. It is only acted on once:  the plan builder
+	// queues an ActionTypeRestoreBackup for it as long as
+	// Status.RestoredFrom does not already match it.
+	Restore *RestoreSpec `json:"restore,omitempty"`
+}
+
+// RestoreSpec identifies the ArangoBackup an ArangoDeployment should be
+// restored from.
+type RestoreSpec struct {
+	// BackupName is the name of the ArangoBackup (in the same namespace)
+	// to restore from.
+	BackupName string `json:"backupName"`
+}
+
+// GetServerGroupSpec returns the ServerGroupSpec configured for group.
+func (s DeploymentSpec) GetServerGroupSpec(group ServerGroup) ServerGroupSpec {
+	switch group {
+	case ServerGroupAgents:
+		return s.Agents
+	case ServerGroupDBServers:
+		return s.DBServers
+	case ServerGroupCoordinators:
+		return s.Coordinators
+	default:
+		return ServerGroupSpec{}
+	}
+}
+
+// ServerGroupSpec holds the desired state of a single server group.
+type ServerGroupSpec struct {
+	// Count is the desired number of members in this group.
+	Count int `json:"count,omitempty"`
+	// Image is the container image to run this group's members with.
+	Image string `json:"image,omitempty"`
+	// Resources are the resource requirements (including storage
+	// requests) for this group's members.
+	Resources core.ResourceRequirements `json:"resources,omitempty"`
+}
+
+// GetCount returns the desired number of members in the group.
+func (s ServerGroupSpec) GetCount() int {
+	return s.Count
+}
+
+// GetImage returns the container image configured for the group.
+func (s ServerGroupSpec) GetImage() string {
+	return s.Image
+}
+
+// GetResources returns the resource requirements configured for the
+// group.
+func (s ServerGroupSpec) GetResources() core.ResourceRequirements {
+	return s.Resources
+}
+
+// DeploymentStatus holds the observed state of an ArangoDeployment.
+type DeploymentStatus struct {
+	// Members holds the observed state of every member, by group.
+	Members MembersStatus `json:"members,omitempty"`
+	// Plan is the ordered list of actions still to be executed to reach
+	// the desired state.
+	Plan Plan `json:"plan,omitempty"`
+	// Drift is the most recent drift report produced by the
+	// driftdetector, or nil if none has run yet.
+	Drift *DriftReport `json:"drift,omitempty"`
+	// RestoredFrom is the name of the ArangoBackup last restored from via
+	// Spec.Restore. Once it matches Spec.Restore.BackupName, the plan
+	// builder considers the requested restore already done and does not
+	// queue another one.
+	RestoredFrom string `json:"restoredFrom,omitempty"`
+}
+
+// MemberStatus holds the observed state of a single member (Pod) of an
+// ArangoDeployment.
+type MemberStatus struct {
+	// ID is the immutable identifier assigned to this member.
+	ID string `json:"id"`
+	// PodName is the name of the Pod currently running this member.
+	PodName string `json:"podName,omitempty"`
+	// PersistentVolumeClaimName is the name of the PersistentVolumeClaim
+	// backing this member's data volume.
+	PersistentVolumeClaimName string `json:"persistentVolumeClaimName,omitempty"`
+	// Image is the container image this member was last recreated with.
+	Image string `json:"image,omitempty"`
+}
+
+// MemberStatusList is a list of MemberStatus, as recorded for a single
+// server group.
+type MemberStatusList []MemberStatus
+
+// ServerGroupMemberStatus pairs a ServerGroup with the MemberStatusList
+// observed for it, as returned by MembersStatus.AsList.
+type ServerGroupMemberStatus struct {
+	Group   ServerGroup
+	Members MemberStatusList
+}
+
+// MembersStatus holds the observed state of every member of an
+// ArangoDeployment, by group.
+type MembersStatus struct {
+	Agents       MemberStatusList `json:"agents,omitempty"`
+	DBServers    MemberStatusList `json:"dbservers,omitempty"`
+	Coordinators MemberStatusList `json:"coordinators,omitempty"`
+}
+
+// AsList returns every group's members paired with its ServerGroup, for
+// code that needs to iterate all members regardless of group.
+func (m MembersStatus) AsList() []ServerGroupMemberStatus {
+	return []ServerGroupMemberStatus{
+		{Group: ServerGroupAgents, Members: m.Agents},
+		{Group: ServerGroupDBServers, Members: m.DBServers},
+		{Group: ServerGroupCoordinators, Members: m.Coordinators},
+	}
+}
+
+// ActionType identifies the kind of operation a single Action performs.
+type ActionType string
+
+const (
+	// ActionTypeAddMember adds a new member to a server group.
+	ActionTypeAddMember ActionType = "AddMember"
+	// ActionTypeRemoveMember removes a member from a server group.
+	ActionTypeRemoveMember ActionType = "RemoveMember"
+	// ActionTypeCleanOutMember cleans out a dbserver member before it is
+	// removed.
+	ActionTypeCleanOutMember ActionType = "CleanOutMember"
+	// ActionTypeShutdownMember gracefully shuts down a member.
+	ActionTypeShutdownMember ActionType = "ShutdownMember"
+	// ActionTypeRotateMember recreates a member's Pod, e.g. to pick up a
+	// changed Pod template.
+	ActionTypeRotateMember ActionType = "RotateMember"
+	// ActionTypeWaitForMemberUp waits for a member to become reachable
+	// again.
+	ActionTypeWaitForMemberUp ActionType = "WaitForMemberUp"
+	// ActionTypeUpgradeImage performs a rolling image change for a
+	// single member.
+	ActionTypeUpgradeImage ActionType = "UpgradeImage"
+	// ActionTypeResizePVC resizes a member's PersistentVolumeClaim.
+	ActionTypeResizePVC ActionType = "ResizePVC"
+	// ActionTypeBackupMember triggers a hot-backup ahead of a
+	// potentially disruptive change to a member.
+	ActionTypeBackupMember ActionType = "BackupMember"
+	// ActionTypeWaitForShardsInSync blocks the plan until every shard's
+	// followers have caught up with their leader.
+	ActionTypeWaitForShardsInSync ActionType = "WaitForShardsInSync"
+	// ActionTypeRestoreBackup restores the cluster from an ArangoBackup.
+	ActionTypeRestoreBackup ActionType = "RestoreBackup"
+)
+
+// Action is a single step of a Deployment's plan.
+type Action struct {
+	ID        string            `json:"id"`
+	Type      ActionType        `json:"type"`
+	Group     ServerGroup       `json:"group,omitempty"`
+	MemberID  string            `json:"memberID,omitempty"`
+	Params    map[string]string `json:"params,omitempty"`
+	StartTime *metav1.Time      `json:"startTime,omitempty"`
+}
+
+// Plan is an ordered list of Actions still to be executed to reach the
+// desired state.
+type Plan []Action
+
+// SpecMismatch describes a single field that diverges between the
+// desired Spec and the live state, identified by its JSON path.
+type SpecMismatch struct {
+	JSONPath string `json:"jsonPath"`
+	Desired  string `json:"desired"`
+	Actual   string `json:"actual"`
+}
+
+// DriftReport is the structured outcome of a single drift-detection run.
+// It is stored verbatim on DeploymentStatus.Drift.
+type DriftReport struct {
+	UnexpectedPods []string       `json:"unexpectedPods,omitempty"`
+	MissingMembers []string       `json:"missingMembers,omitempty"`
+	SpecMismatches []SpecMismatch `json:"specMismatches,omitempty"`
+	LastCheckedAt  metav1.Time    `json:"lastCheckedAt"`
+}
+
+// HasDrift returns true when the report found any difference at all.
+func (r DriftReport) HasDrift() bool {
+	return len(r.UnexpectedPods) > 0 || len(r.MissingMembers) > 0 || len(r.SpecMismatches) > 0
+}
@@ -0,0 +1,347 @@
+//
+// DISCLAIMER
+//
+// Copyright 2018 ArangoDB GmbH, Cologne, Germany
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Copyright holder is ArangoDB GmbH, Cologne, Germany
+//
+
+// Package backup implements the ArangoBackup controller: it creates
+// consistent cluster-wide hot backups through ArangoDB's backup API,
+// optionally uploads them to remote storage, prunes old backups
+// according to their retention policy, and spawns them periodically from
+// ArangoBackups that act as Schedule templates.
+package backup
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"github.com/rs/zerolog"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	driver "github.com/arangodb/go-driver"
+	api "github.com/arangodb/kube-arangodb/pkg/apis/backup/v1alpha"
+	"github.com/arangodb/kube-arangodb/pkg/generated/clientset/versioned"
+	"github.com/arangodb/kube-arangodb/pkg/util/arangod"
+
+	"github.com/pkg/errors"
+	core "k8s.io/client-go/kubernetes"
+)
+
+var maskAny = errors.WithStack
+
+// Controller reconciles ArangoBackup resources.
+type Controller struct {
+	log       zerolog.Logger
+	crCli     versioned.Interface
+	kubeCli   core.Interface
+	namespace string
+}
+
+// New creates a new backup Controller.
+func New(log zerolog.Logger, crCli versioned.Interface, kubeCli core.Interface, namespace string) *Controller {
+	return &Controller{
+		log:       log,
+		crCli:     crCli,
+		kubeCli:   kubeCli,
+		namespace: namespace,
+	}
+}
+
+// Reconcile drives a single ArangoBackup towards its desired state: create
+// the hot-backup if it does not exist yet, upload it when configured, and
+// apply the retention policy.
+func (c *Controller) Reconcile(ctx context.Context, backup *api.ArangoBackup) (*api.ArangoBackup, error) {
+	log := c.log.With().Str("backup", backup.GetName()).Logger()
+
+	if backup.Spec.Schedule != "" && backup.Status.ScheduledFrom == "" {
+		// backup is a Schedule template: it never becomes a hot-backup
+		// itself, it only spawns one from itself on every due tick.
+		updated, err := c.reconcileSchedule(backup)
+		if err != nil {
+			log.Debug().Err(err).Msg("Failed to reconcile backup schedule")
+			return c.setFailedCondition(backup, err), maskAny(err)
+		}
+		return updated, nil
+	}
+
+	if backup.Status.BackupID == "" {
+		updated, err := c.createBackup(ctx, backup)
+		if err != nil {
+			log.Debug().Err(err).Msg("Failed to create backup")
+			return c.setFailedCondition(backup, err), maskAny(err)
+		}
+		backup = updated
+	}
+
+	if backup.Spec.Upload != nil && !hasCondition(backup, api.ArangoBackupConditionUploaded) {
+		updated, err := c.uploadBackup(ctx, backup)
+		if err != nil {
+			log.Debug().Err(err).Msg("Failed to upload backup")
+			return c.setFailedCondition(backup, err), maskAny(err)
+		}
+		backup = updated
+	}
+
+	if err := c.applyRetention(ctx, backup); err != nil {
+		log.Debug().Err(err).Msg("Failed to apply retention policy")
+		return backup, maskAny(err)
+	}
+
+	return backup, nil
+}
+
+// reconcileSchedule creates a new ArangoBackup from backup, a Schedule
+// template, once backup.Spec.Schedule next comes due after
+// backup.Status.LastScheduleTime (or its creation time, if it has never
+// fired). It does nothing if the schedule is not due yet.
+func (c *Controller) reconcileSchedule(backup *api.ArangoBackup) (*api.ArangoBackup, error) {
+	sched, err := cron.ParseStandard(backup.Spec.Schedule)
+	if err != nil {
+		return backup, maskAny(fmt.Errorf("invalid schedule %q: %v", backup.Spec.Schedule, err))
+	}
+
+	last := backup.GetCreationTimestamp().Time
+	if backup.Status.LastScheduleTime != nil {
+		last = backup.Status.LastScheduleTime.Time
+	}
+	if sched.Next(last).After(time.Now()) {
+		return backup, nil
+	}
+
+	child := &api.ArangoBackup{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: backup.GetName() + "-",
+			Namespace:    backup.GetNamespace(),
+		},
+		Spec: api.ArangoBackupSpec{
+			Deployment: backup.Spec.Deployment,
+			Upload:     backup.Spec.Upload,
+			Retention:  backup.Spec.Retention,
+			Schedule:   backup.Spec.Schedule,
+		},
+		Status: api.ArangoBackupStatus{
+			ScheduledFrom: backup.GetName(),
+		},
+	}
+	if _, err := c.crCli.BackupV1alpha().ArangoBackups(backup.GetNamespace()).Create(child); err != nil {
+		return backup, maskAny(err)
+	}
+
+	now := metav1.Now()
+	backup.Status.LastScheduleTime = &now
+	return c.updateStatus(backup)
+}
+
+// createBackup triggers the hot-backup through the ArangoDeployment
+// referenced by backup.Spec.Deployment, and records the resulting backup ID
+// and size on the status.
+func (c *Controller) createBackup(ctx context.Context, backup *api.ArangoBackup) (*api.ArangoBackup, error) {
+	dbcli, err := c.databaseClientFor(ctx, backup.Spec.Deployment)
+	if err != nil {
+		return backup, maskAny(err)
+	}
+
+	req, err := dbcli.Connection().NewRequest("POST", "_admin/backup/create")
+	if err != nil {
+		return backup, maskAny(err)
+	}
+	if _, err := req.SetBody(struct {
+		Label string `json:"label"`
+	}{Label: backup.GetName()}); err != nil {
+		return backup, maskAny(err)
+	}
+	resp, err := dbcli.Connection().Do(ctx, req)
+	if err != nil {
+		return backup, maskAny(err)
+	}
+	if err := resp.CheckStatus(200, 201); err != nil {
+		return backup, maskAny(err)
+	}
+
+	var result struct {
+		Result struct {
+			ID          string `json:"id"`
+			SizeInBytes int64  `json:"sizeInBytes"`
+		} `json:"result"`
+	}
+	if err := resp.ParseBody("", &result); err != nil {
+		return backup, maskAny(err)
+	}
+
+	now := metav1.Now()
+	backup.Status.BackupID = result.Result.ID
+	backup.Status.CreatedAt = &now
+	backup.Status.SizeInBytes = result.Result.SizeInBytes
+	backup = setCondition(backup, api.ArangoBackupConditionAvailable, metav1.ConditionTrue, "Created", "Backup created")
+	return c.updateStatus(backup)
+}
+
+// uploadBackup pushes the backup identified by backup.Status.BackupID to
+// the remote storage configured in backup.Spec.Upload.
+func (c *Controller) uploadBackup(ctx context.Context, backup *api.ArangoBackup) (*api.ArangoBackup, error) {
+	dbcli, err := c.databaseClientFor(ctx, backup.Spec.Deployment)
+	if err != nil {
+		return backup, maskAny(err)
+	}
+
+	req, err := dbcli.Connection().NewRequest("POST", "_admin/backup/upload")
+	if err != nil {
+		return backup, maskAny(err)
+	}
+	if _, err := req.SetBody(struct {
+		ID         string `json:"id"`
+		RemoteRepo string `json:"remoteRepository"`
+	}{
+		ID:         backup.Status.BackupID,
+		RemoteRepo: backup.Spec.Upload.RepositoryURL,
+	}); err != nil {
+		return backup, maskAny(err)
+	}
+	resp, err := dbcli.Connection().Do(ctx, req)
+	if err != nil {
+		return backup, maskAny(err)
+	}
+	if err := resp.CheckStatus(200, 201, 202); err != nil {
+		return backup, maskAny(err)
+	}
+
+	backup = setCondition(backup, api.ArangoBackupConditionUploaded, metav1.ConditionTrue, "Uploaded", "Backup uploaded to "+backup.Spec.Upload.RepositoryURL)
+	return c.updateStatus(backup)
+}
+
+// applyRetention removes older ArangoBackups created from the same
+// schedule as backup, according to backup.Spec.Retention.
+func (c *Controller) applyRetention(ctx context.Context, backup *api.ArangoBackup) error {
+	if backup.Spec.Retention == nil || backup.Spec.Schedule == "" {
+		return nil
+	}
+
+	list, err := c.crCli.BackupV1alpha().ArangoBackups(c.namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return maskAny(err)
+	}
+
+	var siblings []api.ArangoBackup
+	for _, b := range list.Items {
+		if b.Spec.Deployment == backup.Spec.Deployment && b.Spec.Schedule == backup.Spec.Schedule {
+			if b.Status.CreatedAt == nil {
+				// Not created yet (e.g. its own Reconcile hasn't reached
+				// createBackup yet); it isn't a completed backup to keep or
+				// expire, and must not be deleted out from under it.
+				continue
+			}
+			siblings = append(siblings, b)
+		}
+	}
+
+	// List() order is unspecified, but KeepLast below assumes siblings is
+	// ordered most-recent-first.
+	sort.Slice(siblings, func(i, j int) bool {
+		return siblings[j].Status.CreatedAt.Time.Before(siblings[i].Status.CreatedAt.Time)
+	})
+
+	var keepFor time.Duration
+	if backup.Spec.Retention.KeepFor != "" {
+		keepFor, err = time.ParseDuration(backup.Spec.Retention.KeepFor)
+		if err != nil {
+			return maskAny(fmt.Errorf("invalid retention.keepFor %q: %v", backup.Spec.Retention.KeepFor, err))
+		}
+	}
+
+	now := time.Now()
+	kept := 0
+	for _, b := range siblings {
+		expired := keepFor > 0 && now.Sub(b.Status.CreatedAt.Time) > keepFor
+		overLimit := backup.Spec.Retention.KeepLast > 0 && kept >= backup.Spec.Retention.KeepLast
+		if expired || overLimit {
+			if err := c.crCli.BackupV1alpha().ArangoBackups(c.namespace).Delete(b.GetName(), nil); err != nil {
+				return maskAny(err)
+			}
+			continue
+		}
+		kept++
+	}
+	return nil
+}
+
+// databaseClientFor looks up the ArangoDeployment named deploymentName and
+// returns an ArangoDB client for it.
+func (c *Controller) databaseClientFor(ctx context.Context, deploymentName string) (driver.Client, error) {
+	depl, err := c.crCli.Database().ArangoDeployments(c.namespace).Get(deploymentName, metav1.GetOptions{})
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	cli, err := arangod.CreateArangodDatabaseClient(ctx, c.kubeCli.CoreV1(), depl)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	return cli, nil
+}
+
+func (c *Controller) updateStatus(backup *api.ArangoBackup) (*api.ArangoBackup, error) {
+	updated, err := c.crCli.BackupV1alpha().ArangoBackups(c.namespace).Update(backup)
+	if err != nil {
+		return backup, maskAny(err)
+	}
+	return updated, nil
+}
+
+func setCondition(backup *api.ArangoBackup, condType api.ArangoBackupConditionType, status metav1.ConditionStatus, reason, message string) *api.ArangoBackup {
+	now := metav1.Now()
+	for i, cond := range backup.Status.Conditions {
+		if cond.Type == condType {
+			backup.Status.Conditions[i].Status = status
+			backup.Status.Conditions[i].Reason = reason
+			backup.Status.Conditions[i].Message = message
+			backup.Status.Conditions[i].LastTransitionTime = now
+			return backup
+		}
+	}
+	backup.Status.Conditions = append(backup.Status.Conditions, api.ArangoBackupCondition{
+		Type:               condType,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		LastTransitionTime: now,
+	})
+	return backup
+}
+
+// setFailedCondition records the Failed condition for cause and persists it
+// through updateStatus, so the failure is visible on the API server even
+// though Reconcile itself still returns an error for this tick.
+func (c *Controller) setFailedCondition(backup *api.ArangoBackup, cause error) *api.ArangoBackup {
+	backup = setCondition(backup, api.ArangoBackupConditionFailed, metav1.ConditionTrue, "Error", cause.Error())
+	updated, err := c.updateStatus(backup)
+	if err != nil {
+		c.log.Debug().Err(err).Msg("Failed to persist Failed condition")
+		return backup
+	}
+	return updated
+}
+
+func hasCondition(backup *api.ArangoBackup, condType api.ArangoBackupConditionType) bool {
+	for _, cond := range backup.Status.Conditions {
+		if cond.Type == condType && cond.Status == metav1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,131 @@
+//
+// DISCLAIMER
+//
+// Copyright 2018 ArangoDB GmbH, Cologne, Germany
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Copyright holder is ArangoDB GmbH, Cologne, Germany
+//
+
+package deployment
+
+import (
+	"context"
+
+	api "github.com/arangodb/kube-arangodb/pkg/apis/deployment/v1alpha"
+	"github.com/rs/zerolog"
+)
+
+func init() {
+	RegisterAction(api.ActionTypeBackupMember, func(log zerolog.Logger, a api.Action, ctx ActionContext) Action {
+		return NewBackupMemberAction(log, a, ctx)
+	})
+}
+
+// NewBackupMemberAction creates a new Action that triggers a hot-backup of
+// the whole cluster through ArangoDB's backup API, before the member
+// targeted by action is shut down. It is used to give an UpgradeImage
+// rollout a fallback to restore to.
+func NewBackupMemberAction(log zerolog.Logger, action api.Action, actionCtx ActionContext) Action {
+	return &backupMemberAction{
+		log:       log,
+		action:    action,
+		actionCtx: actionCtx,
+	}
+}
+
+// backupMemberAction implements an ActionTypeBackupMember.
+type backupMemberAction struct {
+	log       zerolog.Logger
+	action    api.Action
+	actionCtx ActionContext
+}
+
+// Start triggers the hot-backup creation and returns immediately; the
+// backup itself is tracked by CheckProgress. The label used here is the
+// member ID, which is also how CheckProgress finds the backup back on
+// later reconcile ticks: the ArangoDB-assigned backup ID returned by this
+// call only ever lives in this Start invocation's stack, since a fresh
+// backupMemberAction (with no memory of it) is built from the persisted
+// plan on every tick.
+func (a *backupMemberAction) Start(ctx context.Context) (bool, error) {
+	c, err := a.actionCtx.GetDatabaseClient(ctx)
+	if err != nil {
+		a.log.Debug().Err(err).Msg("Failed to create database client")
+		return false, maskAny(err)
+	}
+	req, err := c.Connection().NewRequest("POST", "_admin/backup/create")
+	if err != nil {
+		return false, maskAny(err)
+	}
+	if _, err := req.SetBody(struct {
+		Label   string `json:"label"`
+		Timeout int    `json:"timeout"`
+	}{
+		Label:   a.action.MemberID,
+		Timeout: 120,
+	}); err != nil {
+		return false, maskAny(err)
+	}
+	resp, err := c.Connection().Do(ctx, req)
+	if err != nil {
+		return false, maskAny(err)
+	}
+	if err := resp.CheckStatus(200, 201); err != nil {
+		return false, maskAny(err)
+	}
+	return false, nil
+}
+
+// backupListEntry is the relevant subset of a single entry in the response
+// of `POST /_admin/backup/list`.
+type backupListEntry struct {
+	Label     string `json:"label"`
+	Available bool   `json:"available"`
+}
+
+// CheckProgress checks whether the backup triggered by Start has finished
+// being created, by finding it back through its label (the member ID)
+// rather than the backup ID that Start never got the chance to persist.
+func (a *backupMemberAction) CheckProgress(ctx context.Context) (bool, error) {
+	c, err := a.actionCtx.GetDatabaseClient(ctx)
+	if err != nil {
+		return false, maskAny(err)
+	}
+	req, err := c.Connection().NewRequest("POST", "_admin/backup/list")
+	if err != nil {
+		return false, maskAny(err)
+	}
+	resp, err := c.Connection().Do(ctx, req)
+	if err != nil {
+		return false, maskAny(err)
+	}
+	if err := resp.CheckStatus(200); err != nil {
+		return false, maskAny(err)
+	}
+	var result struct {
+		Result struct {
+			List map[string]backupListEntry `json:"list"`
+		} `json:"result"`
+	}
+	if err := resp.ParseBody("", &result); err != nil {
+		return false, maskAny(err)
+	}
+	for _, entry := range result.Result.List {
+		if entry.Label == a.action.MemberID && entry.Available {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,49 @@
+//
+// DISCLAIMER
+//
+// Copyright 2018 ArangoDB GmbH, Cologne, Germany
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Copyright holder is ArangoDB GmbH, Cologne, Germany
+//
+
+package deployment
+
+import (
+	api "github.com/arangodb/kube-arangodb/pkg/apis/deployment/v1alpha"
+	"github.com/rs/zerolog"
+)
+
+// init registers the original, hardcoded set of actions with the registry
+// so createAction no longer needs to know about them explicitly.
+func init() {
+	RegisterAction(api.ActionTypeAddMember, func(log zerolog.Logger, a api.Action, ctx ActionContext) Action {
+		return NewAddMemberAction(log, a, ctx)
+	})
+	RegisterAction(api.ActionTypeRemoveMember, func(log zerolog.Logger, a api.Action, ctx ActionContext) Action {
+		return NewRemoveMemberAction(log, a, ctx)
+	})
+	RegisterAction(api.ActionTypeCleanOutMember, func(log zerolog.Logger, a api.Action, ctx ActionContext) Action {
+		return NewCleanOutMemberAction(log, a, ctx)
+	})
+	RegisterAction(api.ActionTypeShutdownMember, func(log zerolog.Logger, a api.Action, ctx ActionContext) Action {
+		return NewShutdownMemberAction(log, a, ctx)
+	})
+	RegisterAction(api.ActionTypeRotateMember, func(log zerolog.Logger, a api.Action, ctx ActionContext) Action {
+		return NewRotateMemberAction(log, a, ctx)
+	})
+	RegisterAction(api.ActionTypeWaitForMemberUp, func(log zerolog.Logger, a api.Action, ctx ActionContext) Action {
+		return NewWaitForMemberUpAction(log, a, ctx)
+	})
+}
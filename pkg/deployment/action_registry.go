@@ -0,0 +1,42 @@
+//
+// DISCLAIMER
+//
+// Copyright 2018 ArangoDB GmbH, Cologne, Germany
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Copyright holder is ArangoDB GmbH, Cologne, Germany
+//
+
+package deployment
+
+import (
+	api "github.com/arangodb/kube-arangodb/pkg/apis/deployment/v1alpha"
+	"github.com/rs/zerolog"
+)
+
+// ActionFactory creates an Action implementation for a single plan action.
+type ActionFactory func(log zerolog.Logger, action api.Action, actionCtx ActionContext) Action
+
+// actionFactories holds the factory registered for every known
+// api.ActionType. Built-in actions register themselves from an init()
+// function in their own file, so adding an action type never again
+// requires touching this registry or the switch in createAction.
+var actionFactories = make(map[api.ActionType]ActionFactory)
+
+// RegisterAction registers factory as the way to create Action instances
+// for actionType. Calling RegisterAction twice for the same type replaces
+// the previously registered factory.
+func RegisterAction(actionType api.ActionType, factory ActionFactory) {
+	actionFactories[actionType] = factory
+}
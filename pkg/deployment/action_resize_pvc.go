@@ -0,0 +1,139 @@
+//
+// DISCLAIMER
+//
+// Copyright 2018 ArangoDB GmbH, Cologne, Germany
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Copyright holder is ArangoDB GmbH, Cologne, Germany
+//
+
+package deployment
+
+import (
+	"context"
+	"fmt"
+
+	core "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	api "github.com/arangodb/kube-arangodb/pkg/apis/deployment/v1alpha"
+	"github.com/rs/zerolog"
+)
+
+func init() {
+	RegisterAction(api.ActionTypeResizePVC, func(log zerolog.Logger, a api.Action, ctx ActionContext) Action {
+		return NewResizePVCAction(log, a, ctx)
+	})
+}
+
+// NewResizePVCAction creates a new Action that edits a member's
+// PersistentVolumeClaim to request the size found in the current spec, and
+// waits for the underlying filesystem to finish resizing.
+func NewResizePVCAction(log zerolog.Logger, action api.Action, actionCtx ActionContext) Action {
+	return &resizePVCAction{
+		log:       log,
+		action:    action,
+		actionCtx: actionCtx,
+	}
+}
+
+// resizePVCAction implements an ActionTypeResizePVC.
+type resizePVCAction struct {
+	log       zerolog.Logger
+	action    api.Action
+	actionCtx ActionContext
+}
+
+// pvcName resolves the name of the PersistentVolumeClaim backing the
+// action's member. It is not the same as MemberID: MemberID identifies the
+// member, not the Kubernetes object names derived from it.
+func (a *resizePVCAction) pvcName() (string, error) {
+	member, found := a.actionCtx.GetMemberStatus(a.action.Group, a.action.MemberID)
+	if !found {
+		return "", maskAny(fmt.Errorf("member %s not found in status", a.action.MemberID))
+	}
+	if member.PersistentVolumeClaimName == "" {
+		return "", maskAny(fmt.Errorf("member %s has no PersistentVolumeClaim recorded", a.action.MemberID))
+	}
+	return member.PersistentVolumeClaimName, nil
+}
+
+// Start patches the member's PVC to request the new storage size from the
+// spec.
+func (a *resizePVCAction) Start(ctx context.Context) (bool, error) {
+	spec := a.actionCtx.GetSpec()
+	groupSpec := spec.GetServerGroupSpec(a.action.Group)
+	wanted := groupSpec.GetResources().Requests[core.ResourceStorage]
+
+	pvcName, err := a.pvcName()
+	if err != nil {
+		return false, maskAny(err)
+	}
+
+	kubecli := a.actionCtx.GetKubeCli()
+	ns := a.actionCtx.GetNamespace()
+	pvcs := kubecli.CoreV1().PersistentVolumeClaims(ns)
+
+	pvc, err := pvcs.Get(pvcName, metav1.GetOptions{})
+	if err != nil {
+		a.log.Debug().Err(err).Msg("Failed to get PVC")
+		return false, maskAny(err)
+	}
+	current := pvc.Spec.Resources.Requests[core.ResourceStorage]
+	if current.Cmp(wanted) >= 0 {
+		// Already at (or beyond) the requested size.
+		return true, nil
+	}
+	pvc.Spec.Resources.Requests[core.ResourceStorage] = wanted
+	if _, err := pvcs.Update(pvc); err != nil {
+		a.log.Debug().Err(err).Msg("Failed to update PVC size")
+		return false, maskAny(err)
+	}
+	return false, nil
+}
+
+// CheckProgress waits for the PVC's FileSystemResizePending condition to
+// clear and its capacity to have caught up with the new request.
+func (a *resizePVCAction) CheckProgress(ctx context.Context) (bool, error) {
+	spec := a.actionCtx.GetSpec()
+	groupSpec := spec.GetServerGroupSpec(a.action.Group)
+	wanted := groupSpec.GetResources().Requests[core.ResourceStorage]
+
+	pvcName, err := a.pvcName()
+	if err != nil {
+		return false, maskAny(err)
+	}
+
+	kubecli := a.actionCtx.GetKubeCli()
+	ns := a.actionCtx.GetNamespace()
+	pvc, err := kubecli.CoreV1().PersistentVolumeClaims(ns).Get(pvcName, metav1.GetOptions{})
+	if err != nil {
+		return false, maskAny(err)
+	}
+
+	for _, cond := range pvc.Status.Conditions {
+		if cond.Type == core.PersistentVolumeClaimResizing && cond.Status == core.ConditionTrue {
+			return false, nil
+		}
+		if cond.Type == core.PersistentVolumeClaimFileSystemResizePending && cond.Status == core.ConditionTrue {
+			return false, nil
+		}
+	}
+
+	capacity := pvc.Status.Capacity[core.ResourceStorage]
+	if capacity.Cmp(wanted) < 0 {
+		return false, nil
+	}
+	return true, nil
+}
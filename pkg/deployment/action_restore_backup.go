@@ -0,0 +1,111 @@
+//
+// DISCLAIMER
+//
+// Copyright 2018 ArangoDB GmbH, Cologne, Germany
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Copyright holder is ArangoDB GmbH, Cologne, Germany
+//
+
+package deployment
+
+import (
+	"context"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	api "github.com/arangodb/kube-arangodb/pkg/apis/deployment/v1alpha"
+	"github.com/rs/zerolog"
+)
+
+func init() {
+	RegisterAction(api.ActionTypeRestoreBackup, func(log zerolog.Logger, a api.Action, ctx ActionContext) Action {
+		return NewRestoreBackupAction(log, a, ctx)
+	})
+}
+
+// NewRestoreBackupAction creates a new Action that restores the cluster
+// from the ArangoBackup named in action.Params["backupName"], through
+// ArangoDB's `/_admin/backup/restore` endpoint. It is queued by the plan
+// builder whenever ArangoDeployment.Spec.Restore is set to a backup not
+// yet reflected by Status.RestoredFrom.
+func NewRestoreBackupAction(log zerolog.Logger, action api.Action, actionCtx ActionContext) Action {
+	return &restoreBackupAction{
+		log:       log,
+		action:    action,
+		actionCtx: actionCtx,
+	}
+}
+
+// restoreBackupAction implements an ActionTypeRestoreBackup.
+type restoreBackupAction struct {
+	log       zerolog.Logger
+	action    api.Action
+	actionCtx ActionContext
+}
+
+// Start resolves the ArangoBackup's ArangoDB-assigned backup ID and
+// triggers the restore, returning immediately; completion is observed
+// through CheckProgress.
+func (a *restoreBackupAction) Start(ctx context.Context) (bool, error) {
+	backup, err := a.actionCtx.GetCRCli().BackupV1alpha().ArangoBackups(a.actionCtx.GetNamespace()).
+		Get(a.action.Params["backupName"], metav1.GetOptions{})
+	if err != nil {
+		a.log.Debug().Err(err).Msg("Failed to get ArangoBackup")
+		return false, maskAny(err)
+	}
+	if backup.Status.BackupID == "" {
+		return false, maskAny(fmt.Errorf("ArangoBackup %s has no backupID yet", backup.GetName()))
+	}
+
+	c, err := a.actionCtx.GetDatabaseClient(ctx)
+	if err != nil {
+		a.log.Debug().Err(err).Msg("Failed to create database client")
+		return false, maskAny(err)
+	}
+	req, err := c.Connection().NewRequest("POST", "_admin/backup/restore")
+	if err != nil {
+		return false, maskAny(err)
+	}
+	if _, err := req.SetBody(struct {
+		ID string `json:"id"`
+	}{ID: backup.Status.BackupID}); err != nil {
+		return false, maskAny(err)
+	}
+	resp, err := c.Connection().Do(ctx, req)
+	if err != nil {
+		a.log.Debug().Err(err).Msg("Failed to start restore")
+		return false, maskAny(err)
+	}
+	if err := resp.CheckStatus(200, 201, 202); err != nil {
+		return false, maskAny(err)
+	}
+	return false, nil
+}
+
+// CheckProgress waits until the deployment reports its usual version
+// endpoint again, which only succeeds once the restore has completed and
+// the servers have come back up.
+func (a *restoreBackupAction) CheckProgress(ctx context.Context) (bool, error) {
+	c, err := a.actionCtx.GetDatabaseClient(ctx)
+	if err != nil {
+		// Servers may still be restarting as part of the restore.
+		return false, nil
+	}
+	if _, err := c.Version(ctx); err != nil {
+		return false, nil
+	}
+	return true, nil
+}
@@ -0,0 +1,172 @@
+//
+// DISCLAIMER
+//
+// Copyright 2018 ArangoDB GmbH, Cologne, Germany
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Copyright holder is ArangoDB GmbH, Cologne, Germany
+//
+
+package deployment
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	driver "github.com/arangodb/go-driver"
+	api "github.com/arangodb/kube-arangodb/pkg/apis/deployment/v1alpha"
+	"github.com/rs/zerolog"
+)
+
+func init() {
+	RegisterAction(api.ActionTypeUpgradeImage, func(log zerolog.Logger, a api.Action, ctx ActionContext) Action {
+		return NewUpgradeImageAction(log, a, ctx)
+	})
+}
+
+// NewUpgradeImageAction creates a new Action that performs a rolling image
+// change for a single member, after checking that the new version is
+// compatible with the version currently running on the rest of the
+// deployment.
+func NewUpgradeImageAction(log zerolog.Logger, action api.Action, actionCtx ActionContext) Action {
+	return &upgradeImageAction{
+		log:       log,
+		action:    action,
+		actionCtx: actionCtx,
+	}
+}
+
+// upgradeImageAction implements an ActionTypeUpgradeImage.
+type upgradeImageAction struct {
+	log       zerolog.Logger
+	action    api.Action
+	actionCtx ActionContext
+}
+
+// Start verifies that the new image's version is compatible with the
+// version currently running elsewhere in the deployment, then deletes the
+// member's Pod so it is recreated with the new image on its next start.
+func (a *upgradeImageAction) Start(ctx context.Context) (bool, error) {
+	targetVersion, err := versionFromImage(a.action.Params["image"])
+	if err != nil {
+		return false, maskAny(err)
+	}
+
+	c, err := a.actionCtx.GetDatabaseClient(ctx)
+	if err != nil {
+		a.log.Debug().Err(err).Msg("Failed to create database client")
+		return false, maskAny(err)
+	}
+	current, err := c.Version(ctx)
+	if err != nil {
+		return false, maskAny(err)
+	}
+	if current.Version.CompareTo(targetVersion) == 0 {
+		// Already running the target version.
+		return true, nil
+	}
+	if err := createEqualVersionsPredicate(current)(targetVersion); err != nil {
+		return false, maskAny(err)
+	}
+
+	if err := a.actionCtx.DeletePod(a.action.MemberID); err != nil {
+		a.log.Debug().Err(err).Msg("Failed to delete pod for image upgrade")
+		return false, maskAny(err)
+	}
+	return false, nil
+}
+
+// CheckProgress checks whether the member is back up and reachable again
+// after the Pod recreated by Start has picked up the new image.
+func (a *upgradeImageAction) CheckProgress(ctx context.Context) (bool, error) {
+	c, err := a.actionCtx.GetServerClient(ctx, a.action.Group, a.action.MemberID)
+	if err != nil {
+		// Member Pod may still be (re)starting.
+		return false, nil
+	}
+	if _, err := c.Version(ctx); err != nil {
+		return false, nil
+	}
+	return true, nil
+}
+
+// createEqualVersionsPredicate creates a predicate that checks a candidate
+// target version against current, rejecting any upgrade that is not a
+// single rolling step: the major version must match, and the minor
+// version may advance by at most one (e.g. 3.7 -> 3.8 is allowed, 3.7 ->
+// 3.9 is not, matching ArangoDB's own supported upgrade path).
+func createEqualVersionsPredicate(current driver.VersionInfo) func(target driver.Version) error {
+	return func(target driver.Version) error {
+		currentMajor, currentMinor, err := majorMinor(current.Version)
+		if err != nil {
+			return maskAny(err)
+		}
+		targetMajor, targetMinor, err := majorMinor(target)
+		if err != nil {
+			return maskAny(err)
+		}
+		if currentMajor != targetMajor || targetMinor-currentMinor > 1 || targetMinor < currentMinor {
+			return maskAny(versionMismatchError{wanted: current.Version, got: target})
+		}
+		return nil
+	}
+}
+
+// majorMinor splits an ArangoDB version string such as "3.8.1" into its
+// major ("3") and minor (8) components.
+func majorMinor(v driver.Version) (string, int, error) {
+	parts := strings.SplitN(string(v), ".", 3)
+	if len(parts) < 2 {
+		return "", 0, fmt.Errorf("version %q has no minor component", v)
+	}
+	minor, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return "", 0, fmt.Errorf("version %q has a non-numeric minor component: %v", v, err)
+	}
+	return parts[0], minor, nil
+}
+
+// versionMismatchError is returned by createEqualVersionsPredicate when
+// upgrading from one ArangoDB version to another is not a single
+// supported rolling step.
+type versionMismatchError struct {
+	wanted driver.Version
+	got    driver.Version
+}
+
+func (e versionMismatchError) Error() string {
+	return "version " + string(e.got) + " is not compatible with currently running version " + string(e.wanted)
+}
+
+// versionFromImage derives the ArangoDB version from an image reference of
+// the form "repo/name:version", as used in ArangoDeployment image specs.
+func versionFromImage(image string) (driver.Version, error) {
+	idx := strings.LastIndex(image, ":")
+	if idx < 0 || idx == len(image)-1 {
+		return "", maskAny(errImageHasNoVersionTag{image: image})
+	}
+	return driver.Version(image[idx+1:]), nil
+}
+
+// errImageHasNoVersionTag is returned by versionFromImage when image has no
+// ":tag" suffix to derive a version from.
+type errImageHasNoVersionTag struct {
+	image string
+}
+
+func (e errImageHasNoVersionTag) Error() string {
+	return "image " + e.image + " has no version tag"
+}
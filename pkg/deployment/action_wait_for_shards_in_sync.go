@@ -0,0 +1,108 @@
+//
+// DISCLAIMER
+//
+// Copyright 2018 ArangoDB GmbH, Cologne, Germany
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Copyright holder is ArangoDB GmbH, Cologne, Germany
+//
+
+package deployment
+
+import (
+	"context"
+
+	api "github.com/arangodb/kube-arangodb/pkg/apis/deployment/v1alpha"
+	"github.com/rs/zerolog"
+)
+
+func init() {
+	RegisterAction(api.ActionTypeWaitForShardsInSync, func(log zerolog.Logger, a api.Action, ctx ActionContext) Action {
+		return NewWaitForShardsInSyncAction(log, a, ctx)
+	})
+}
+
+// NewWaitForShardsInSyncAction creates a new Action that blocks the plan
+// until every shard reports its leader and all followers in sync, as
+// reported by `/_admin/cluster/shardDistribution`. It is used before
+// actions that remove or shut down a DBServer, so no write is ever routed
+// to a follower that has not caught up yet.
+func NewWaitForShardsInSyncAction(log zerolog.Logger, action api.Action, actionCtx ActionContext) Action {
+	return &waitForShardsInSyncAction{
+		log:       log,
+		action:    action,
+		actionCtx: actionCtx,
+	}
+}
+
+// waitForShardsInSyncAction implements an ActionTypeWaitForShardsInSync.
+type waitForShardsInSyncAction struct {
+	log       zerolog.Logger
+	action    api.Action
+	actionCtx ActionContext
+}
+
+// shardDistributionResponse is the relevant subset of the response of
+// `GET /_admin/cluster/shardDistribution`.
+type shardDistributionResponse struct {
+	Results map[string]struct {
+		Current map[string]struct {
+			Leader    string   `json:"leader"`
+			Followers []string `json:"followers"`
+			Current   []string `json:"current"`
+		} `json:"Current"`
+	} `json:"results"`
+}
+
+// Start has nothing to kick off server-side, so it always moves straight
+// to CheckProgress.
+func (a *waitForShardsInSyncAction) Start(ctx context.Context) (bool, error) {
+	return a.CheckProgress(ctx)
+}
+
+// CheckProgress queries the shard distribution and reports ready once every
+// shard's `current` list has at least as many entries as its leader plus
+// its configured followers. Only the counts are compared.
+func (a *waitForShardsInSyncAction) CheckProgress(ctx context.Context) (bool, error) {
+	c, err := a.actionCtx.GetDatabaseClient(ctx)
+	if err != nil {
+		a.log.Debug().Err(err).Msg("Failed to create database client")
+		return false, maskAny(err)
+	}
+	req, err := c.Connection().NewRequest("GET", "_admin/cluster/shardDistribution")
+	if err != nil {
+		return false, maskAny(err)
+	}
+	resp, err := c.Connection().Do(ctx, req)
+	if err != nil {
+		return false, maskAny(err)
+	}
+	if err := resp.CheckStatus(200); err != nil {
+		return false, maskAny(err)
+	}
+	var result shardDistributionResponse
+	if err := resp.ParseBody("", &result); err != nil {
+		return false, maskAny(err)
+	}
+
+	for _, collection := range result.Results {
+		for _, shard := range collection.Current {
+			if len(shard.Current) < len(shard.Followers)+1 {
+				// Not every follower has caught up yet.
+				return false, nil
+			}
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,217 @@
+//
+// DISCLAIMER
+//
+// Copyright 2018 ArangoDB GmbH, Cologne, Germany
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Copyright holder is ArangoDB GmbH, Cologne, Germany
+//
+
+// Package driftdetector compares the live state of an ArangoDeployment,
+// as maintained by pkg/deployment/livestate, against its desired Spec and
+// reports the differences found. This mirrors the livestatestore/
+// driftdetector split used by PipeCD.
+package driftdetector
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	api "github.com/arangodb/kube-arangodb/pkg/apis/deployment/v1alpha"
+	"github.com/arangodb/kube-arangodb/pkg/deployment/livestate"
+)
+
+var driftItemsGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "arango_deployment_drift_items",
+	Help: "Number of drift items detected for an ArangoDeployment.",
+}, []string{"namespace", "deployment"})
+
+func init() {
+	prometheus.MustRegister(driftItemsGauge)
+}
+
+// SpecMismatch and DriftReport are owned by the API package so that
+// DeploymentStatus.Drift can store a DriftReport directly without the API
+// package importing this one (this package already imports api for
+// DeploymentSpec/DeploymentStatus, so the reverse import would be a
+// cycle). They are aliased here so existing callers of this package don't
+// need to import api themselves.
+type SpecMismatch = api.SpecMismatch
+type DriftReport = api.DriftReport
+
+// Detector compares a livestate.Store snapshot against an ArangoDeployment
+// spec on every Detect call.
+type Detector struct {
+	namespace    string
+	deploymentID string
+	store        *livestate.Store
+
+	mu         sync.RWMutex
+	lastReport *DriftReport
+}
+
+// NewDetector creates a Detector that evaluates drift for the ArangoDeployment
+// identified by namespace/deploymentID, using store as its source of live
+// state.
+func NewDetector(namespace, deploymentID string, store *livestate.Store) *Detector {
+	d := &Detector{
+		namespace:    namespace,
+		deploymentID: deploymentID,
+		store:        store,
+	}
+	registerDetector(namespace, deploymentID, d)
+	return d
+}
+
+// LastReport returns the report produced by the most recent Detect call,
+// or nil if Detect has not run yet.
+func (d *Detector) LastReport() *DriftReport {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.lastReport
+}
+
+// Handler serves the most recent DriftReport as JSON on GET requests, so
+// operators can inspect it with `kubectl port-forward` + curl without
+// reading the ArangoDeployment's status field.
+func (d *Detector) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := d.LastReport()
+		if report == nil {
+			http.Error(w, "no drift report yet", http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(report)
+	})
+}
+
+// Detect takes a fresh snapshot from the Detector's Store, compares it
+// against spec and returns a DriftReport. It also updates the
+// arango_deployment_drift_items Prometheus gauge.
+func (d *Detector) Detect(spec api.DeploymentSpec, status api.DeploymentStatus) DriftReport {
+	snap := d.store.Snapshot()
+	report := DriftReport{
+		LastCheckedAt: metav1.Now(),
+	}
+
+	expectedMemberIDs := make(map[string]struct{})
+	for _, group := range status.Members.AsList() {
+		for _, m := range group.Members {
+			expectedMemberIDs[m.ID] = struct{}{}
+		}
+	}
+
+	for podName := range snap.Pods {
+		if _, found := expectedMemberIDs[podName]; !found {
+			if _, isMember := findMemberByPodName(status, podName); !isMember {
+				report.UnexpectedPods = append(report.UnexpectedPods, podName)
+			}
+		}
+	}
+
+	for id := range expectedMemberIDs {
+		if _, found := snap.Members[id]; !found {
+			report.MissingMembers = append(report.MissingMembers, id)
+		}
+	}
+
+	if got, want := len(snap.Members), spec.Agents.GetCount()+spec.DBServers.GetCount()+spec.Coordinators.GetCount(); got != want {
+		report.SpecMismatches = append(report.SpecMismatches, SpecMismatch{
+			JSONPath: "$.spec.{agents,dbservers,coordinators}.count",
+			Desired:  fmt.Sprintf("%d", want),
+			Actual:   fmt.Sprintf("%d", got),
+		})
+	}
+
+	// Both slices are built from map iteration above, whose order is
+	// randomized per run; sort them so two reports describing the same
+	// drift always compare equal regardless of iteration order.
+	sort.Strings(report.UnexpectedPods)
+	sort.Strings(report.MissingMembers)
+
+	driftItemsGauge.WithLabelValues(d.namespace, d.deploymentID).Set(float64(len(report.UnexpectedPods) + len(report.MissingMembers) + len(report.SpecMismatches)))
+
+	d.mu.Lock()
+	d.lastReport = &report
+	d.mu.Unlock()
+
+	return report
+}
+
+var (
+	detectorsMu sync.RWMutex
+	detectors   = make(map[string]*Detector)
+)
+
+func detectorKey(namespace, deploymentID string) string {
+	return namespace + "/" + deploymentID
+}
+
+// registerDetector makes d reachable through ServeHTTP under
+// namespace/deploymentID, replacing any Detector previously registered for
+// the same ArangoDeployment (e.g. after the operator restarted watching
+// it).
+func registerDetector(namespace, deploymentID string, d *Detector) {
+	detectorsMu.Lock()
+	defer detectorsMu.Unlock()
+	detectors[detectorKey(namespace, deploymentID)] = d
+}
+
+// UnregisterDetector removes the Detector registered for namespace/
+// deploymentID, if any, so ServeHTTP stops serving its (increasingly
+// stale) report and it can be garbage collected. Callers should invoke
+// this when an ArangoDeployment is deleted, alongside stopping its
+// livestate.Store.
+func UnregisterDetector(namespace, deploymentID string) {
+	detectorsMu.Lock()
+	defer detectorsMu.Unlock()
+	delete(detectors, detectorKey(namespace, deploymentID))
+}
+
+// ServeHTTP serves the DriftReport of a single ArangoDeployment, selected
+// by its "namespace" and "deployment" query parameters. It is meant to be
+// mounted as the operator's `/drift` endpoint, next to `/metrics`.
+func ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	namespace := r.URL.Query().Get("namespace")
+	deploymentID := r.URL.Query().Get("deployment")
+
+	detectorsMu.RLock()
+	d, found := detectors[detectorKey(namespace, deploymentID)]
+	detectorsMu.RUnlock()
+	if !found {
+		http.Error(w, fmt.Sprintf("no drift detector registered for %s/%s", namespace, deploymentID), http.StatusNotFound)
+		return
+	}
+	d.Handler().ServeHTTP(w, r)
+}
+
+// findMemberByPodName reports whether podName belongs to any known member
+// across all server groups.
+func findMemberByPodName(status api.DeploymentStatus, podName string) (api.MemberStatus, bool) {
+	for _, group := range status.Members.AsList() {
+		for _, m := range group.Members {
+			if m.PodName == podName {
+				return m, true
+			}
+		}
+	}
+	return api.MemberStatus{}, false
+}
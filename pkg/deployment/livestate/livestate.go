@@ -0,0 +1,309 @@
+//
+// DISCLAIMER
+//
+// Copyright 2018 ArangoDB GmbH, Cologne, Germany
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Copyright holder is ArangoDB GmbH, Cologne, Germany
+//
+
+// Package livestate maintains an in-memory snapshot of the actual state of a
+// single ArangoDeployment, fed by Kubernetes informers plus a periodic poll
+// of the ArangoDB API. It is the single source of truth the driftdetector
+// package compares the desired spec against, following the
+// livestatestore/driftdetector split used by PipeCD.
+package livestate
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	core "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+
+	driver "github.com/arangodb/go-driver"
+)
+
+// deploymentLabelKey is the label the operator puts on every Pod, PVC and
+// Service it creates for an ArangoDeployment, naming the ArangoDeployment
+// it belongs to.
+const deploymentLabelKey = "arango_deployment"
+
+// MemberHealth is the last known health of a single ArangoDB server, as
+// reported by the ArangoDB API.
+type MemberHealth struct {
+	ID       string
+	Role     driver.ServerRole
+	Status   driver.ServerStatus
+	LastSeen time.Time
+}
+
+// Snapshot is an immutable copy of the live state at a point in time.
+type Snapshot struct {
+	Pods       map[string]*core.Pod
+	PVCs       map[string]*core.PersistentVolumeClaim
+	Services   map[string]*core.Service
+	Members    map[string]MemberHealth
+	ObservedAt time.Time
+}
+
+// Store keeps a live, continuously updated Snapshot for a single
+// ArangoDeployment, built from Kubernetes informer events and a periodic
+// ArangoDB health poll.
+type Store struct {
+	mu           sync.RWMutex
+	namespace    string
+	deploymentID string
+	snapshot     Snapshot
+
+	kubecli      kubernetes.Interface
+	dbclient     func(ctx context.Context) (driver.Client, error)
+	pollInterval time.Duration
+
+	stopCh   chan struct{}
+	stopOnce sync.Once
+}
+
+// NewStore creates a Store for the ArangoDeployment identified by
+// deploymentID in namespace. dbclient is called on every poll tick to
+// obtain a fresh ArangoDB client; it may return an error while the
+// deployment is still starting up.
+func NewStore(kubecli kubernetes.Interface, namespace, deploymentID string, dbclient func(ctx context.Context) (driver.Client, error), pollInterval time.Duration) *Store {
+	if pollInterval <= 0 {
+		pollInterval = 10 * time.Second
+	}
+	return &Store{
+		namespace:    namespace,
+		deploymentID: deploymentID,
+		kubecli:      kubecli,
+		dbclient:     dbclient,
+		pollInterval: pollInterval,
+		snapshot: Snapshot{
+			Pods:     make(map[string]*core.Pod),
+			PVCs:     make(map[string]*core.PersistentVolumeClaim),
+			Services: make(map[string]*core.Service),
+			Members:  make(map[string]MemberHealth),
+		},
+	}
+}
+
+// Start begins watching Kubernetes resources through informers and polling
+// ArangoDB's cluster health, until ctx is canceled or Stop is called.
+func (s *Store) Start(ctx context.Context) {
+	s.stopCh = make(chan struct{})
+
+	factory := informers.NewSharedInformerFactoryWithOptions(s.kubecli, s.pollInterval,
+		informers.WithNamespace(s.namespace),
+		informers.WithTweakListOptions(func(opts *metav1.ListOptions) {
+			opts.LabelSelector = deploymentLabelKey + "=" + s.deploymentID
+		}))
+
+	podInformer := factory.Core().V1().Pods().Informer()
+	podInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { s.setPod(obj) },
+		UpdateFunc: func(_, obj interface{}) { s.setPod(obj) },
+		DeleteFunc: func(obj interface{}) { s.deletePod(obj) },
+	})
+
+	pvcInformer := factory.Core().V1().PersistentVolumeClaims().Informer()
+	pvcInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { s.setPVC(obj) },
+		UpdateFunc: func(_, obj interface{}) { s.setPVC(obj) },
+		DeleteFunc: func(obj interface{}) { s.deletePVC(obj) },
+	})
+
+	svcInformer := factory.Core().V1().Services().Informer()
+	svcInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { s.setService(obj) },
+		UpdateFunc: func(_, obj interface{}) { s.setService(obj) },
+		DeleteFunc: func(obj interface{}) { s.deleteService(obj) },
+	})
+
+	factory.Start(s.stopCh)
+	factory.WaitForCacheSync(s.stopCh)
+
+	go s.pollMembersLoop(ctx)
+
+	go func() {
+		<-ctx.Done()
+		s.closeStopCh()
+	}()
+}
+
+// Stop terminates the informers and the polling loop started by Start.
+func (s *Store) Stop() {
+	s.closeStopCh()
+}
+
+// closeStopCh closes stopCh exactly once, regardless of how many times it
+// is called or whether Stop and ctx cancellation race each other.
+func (s *Store) closeStopCh() {
+	if s.stopCh == nil {
+		return
+	}
+	s.stopOnce.Do(func() {
+		close(s.stopCh)
+	})
+}
+
+// Snapshot returns a copy of the current live state.
+func (s *Store) Snapshot() Snapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	snap := Snapshot{
+		Pods:       make(map[string]*core.Pod, len(s.snapshot.Pods)),
+		PVCs:       make(map[string]*core.PersistentVolumeClaim, len(s.snapshot.PVCs)),
+		Services:   make(map[string]*core.Service, len(s.snapshot.Services)),
+		Members:    make(map[string]MemberHealth, len(s.snapshot.Members)),
+		ObservedAt: s.snapshot.ObservedAt,
+	}
+	for k, v := range s.snapshot.Pods {
+		snap.Pods[k] = v
+	}
+	for k, v := range s.snapshot.PVCs {
+		snap.PVCs[k] = v
+	}
+	for k, v := range s.snapshot.Services {
+		snap.Services[k] = v
+	}
+	for k, v := range s.snapshot.Members {
+		snap.Members[k] = v
+	}
+	return snap
+}
+
+func (s *Store) pollMembersLoop(ctx context.Context) {
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.stopCh:
+			return
+		case <-ticker.C:
+			s.pollMembers(ctx)
+		}
+	}
+}
+
+func (s *Store) pollMembers(ctx context.Context) {
+	client, err := s.dbclient(ctx)
+	if err != nil {
+		// Deployment not reachable yet; keep the last known member state.
+		return
+	}
+	cluster, err := client.Cluster(ctx)
+	if err != nil {
+		return
+	}
+	health, err := cluster.Health(ctx)
+	if err != nil {
+		return
+	}
+
+	now := time.Now()
+	members := make(map[string]MemberHealth, len(health.Health))
+	for id, h := range health.Health {
+		members[string(id)] = MemberHealth{
+			ID:       string(id),
+			Role:     h.Role,
+			Status:   h.Status,
+			LastSeen: now,
+		}
+	}
+
+	s.mu.Lock()
+	s.snapshot.Members = members
+	s.snapshot.ObservedAt = now
+	s.mu.Unlock()
+}
+
+func (s *Store) setPod(obj interface{}) {
+	pod, ok := obj.(*core.Pod)
+	if !ok {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.snapshot.Pods[pod.Name] = pod
+	s.snapshot.ObservedAt = time.Now()
+}
+
+func (s *Store) deletePod(obj interface{}) {
+	pod, ok := obj.(*core.Pod)
+	if !ok {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.snapshot.Pods, pod.Name)
+	s.snapshot.ObservedAt = time.Now()
+}
+
+func (s *Store) setPVC(obj interface{}) {
+	pvc, ok := obj.(*core.PersistentVolumeClaim)
+	if !ok {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.snapshot.PVCs[pvc.Name] = pvc
+	s.snapshot.ObservedAt = time.Now()
+}
+
+func (s *Store) deletePVC(obj interface{}) {
+	pvc, ok := obj.(*core.PersistentVolumeClaim)
+	if !ok {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.snapshot.PVCs, pvc.Name)
+	s.snapshot.ObservedAt = time.Now()
+}
+
+func (s *Store) setService(obj interface{}) {
+	svc, ok := obj.(*core.Service)
+	if !ok {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.snapshot.Services[svc.Name] = svc
+	s.snapshot.ObservedAt = time.Now()
+}
+
+func (s *Store) deleteService(obj interface{}) {
+	svc, ok := obj.(*core.Service)
+	if !ok {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.snapshot.Services, svc.Name)
+	s.snapshot.ObservedAt = time.Now()
+}
+
+// ArangoDeploymentID returns the name of the ArangoDeployment this store
+// tracks, for use by callers that only hold a reference to the store.
+func (s *Store) ArangoDeploymentID() string {
+	return s.deploymentID
+}
@@ -0,0 +1,97 @@
+//
+// DISCLAIMER
+//
+// Copyright 2018 ArangoDB GmbH, Cologne, Germany
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Copyright holder is ArangoDB GmbH, Cologne, Germany
+//
+
+package deployment
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	api "github.com/arangodb/kube-arangodb/pkg/apis/deployment/v1alpha"
+)
+
+var (
+	planActionsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "arango_plan_actions_total",
+		Help: "Number of plan actions executed, by type and result.",
+	}, []string{"type", "result"})
+
+	planActionDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "arango_plan_action_duration_seconds",
+		Help: "Time spent from an action's recorded start until CheckProgress reports it ready, or until it times out.",
+	}, []string{"type"})
+
+	planLength = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "arango_plan_length",
+		Help: "Number of actions remaining in the plan of an ArangoDeployment.",
+	}, []string{"namespace", "deployment"})
+)
+
+func init() {
+	prometheus.MustRegister(planActionsTotal, planActionDuration, planLength)
+}
+
+// planActionResult labels the outcome of a single plan action for the
+// arango_plan_actions_total counter.
+type planActionResult string
+
+const (
+	planActionResultStarted   planActionResult = "started"
+	planActionResultCompleted planActionResult = "completed"
+	planActionResultFailed    planActionResult = "failed"
+	planActionResultTimedOut  planActionResult = "timed_out"
+)
+
+func observeActionResult(actionType api.ActionType, result planActionResult) {
+	planActionsTotal.WithLabelValues(string(actionType), string(result)).Inc()
+}
+
+func observeActionDuration(actionType api.ActionType, d time.Duration) {
+	planActionDuration.WithLabelValues(string(actionType)).Observe(d.Seconds())
+}
+
+func observePlanLength(namespace, deploymentName string, length int) {
+	planLength.WithLabelValues(namespace, deploymentName).Set(float64(length))
+}
+
+// defaultMaxActionDuration is how long a single action is allowed to sit in
+// CheckProgress before it is considered stuck, for action types not listed
+// explicitly in maxActionDurations.
+const defaultMaxActionDuration = 15 * time.Minute
+
+// maxActionDurations overrides defaultMaxActionDuration for action types
+// known to legitimately take longer (or that must fail fast).
+var maxActionDurations = map[api.ActionType]time.Duration{
+	api.ActionTypeCleanOutMember:      time.Hour,
+	api.ActionTypeResizePVC:           time.Hour,
+	api.ActionTypeRestoreBackup:       2 * time.Hour,
+	api.ActionTypeWaitForShardsInSync: time.Hour,
+	api.ActionTypeBackupMember:        30 * time.Minute,
+}
+
+// maxActionDuration returns the timeout after which an action of the given
+// type is considered stuck and aborted.
+func maxActionDuration(actionType api.ActionType) time.Duration {
+	if d, found := maxActionDurations[actionType]; found {
+		return d
+	}
+	return defaultMaxActionDuration
+}
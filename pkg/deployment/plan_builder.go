@@ -0,0 +1,120 @@
+//
+// DISCLAIMER
+//
+// Copyright 2018 ArangoDB GmbH, Cologne, Germany
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Copyright holder is ArangoDB GmbH, Cologne, Germany
+//
+
+package deployment
+
+import (
+	"github.com/dchest/uniuri"
+
+	core "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	api "github.com/arangodb/kube-arangodb/pkg/apis/deployment/v1alpha"
+)
+
+// createPlan compares the current Spec against the observed Status and
+// returns the actions needed to reconcile them, or nil if nothing needs
+// to change. It is called by executePlan whenever the plan is empty.
+func (d *Deployment) createPlan() api.Plan {
+	if plan := d.createRestorePlan(); len(plan) > 0 {
+		// A pending restore takes priority over, and supersedes, any
+		// other reconciliation: there is no point rolling out a member's
+		// image or resizing its PVC right before the whole cluster's
+		// data is replaced.
+		return plan
+	}
+
+	var plan api.Plan
+	for _, group := range d.status.Members.AsList() {
+		plan = append(plan, d.createGroupPlan(group)...)
+	}
+	return plan
+}
+
+// createRestorePlan returns a single ActionTypeRestoreBackup action when
+// Spec.Restore names a backup that Status.RestoredFrom does not yet
+// reflect, or nil otherwise.
+func (d *Deployment) createRestorePlan() api.Plan {
+	restore := d.apiObject.Spec.Restore
+	if restore == nil || restore.BackupName == "" || restore.BackupName == d.status.RestoredFrom {
+		return nil
+	}
+	return api.Plan{newPlanAction(api.ActionTypeRestoreBackup, "", "", map[string]string{"backupName": restore.BackupName})}
+}
+
+// createGroupPlan returns the actions needed to bring every member of
+// group in line with its ServerGroupSpec.
+func (d *Deployment) createGroupPlan(group api.ServerGroupMemberStatus) api.Plan {
+	groupSpec := d.apiObject.Spec.GetServerGroupSpec(group.Group)
+
+	var plan api.Plan
+	for _, m := range group.Members {
+		if groupSpec.GetImage() != "" && m.Image != "" && m.Image != groupSpec.GetImage() {
+			plan = append(plan, d.createUpgradeImagePlan(group.Group, m, groupSpec)...)
+		}
+		if d.resizePVCNeeded(m, groupSpec) {
+			plan = append(plan, newPlanAction(api.ActionTypeResizePVC, group.Group, m.ID, nil))
+		}
+	}
+	return plan
+}
+
+// createUpgradeImagePlan queues the sequence used to roll a single member
+// onto groupSpec's image: a hot-backup first, so a failed upgrade has
+// something to restore to, then the image change itself. DBServers wait
+// for their shards to be back in sync before the backup is taken, so it
+// captures a consistent state.
+func (d *Deployment) createUpgradeImagePlan(group api.ServerGroup, m api.MemberStatus, groupSpec api.ServerGroupSpec) api.Plan {
+	var plan api.Plan
+	if group == api.ServerGroupDBServers {
+		plan = append(plan, newPlanAction(api.ActionTypeWaitForShardsInSync, group, m.ID, nil))
+	}
+	plan = append(plan,
+		newPlanAction(api.ActionTypeBackupMember, group, m.ID, nil),
+		newPlanAction(api.ActionTypeUpgradeImage, group, m.ID, map[string]string{"image": groupSpec.GetImage()}),
+	)
+	return plan
+}
+
+// resizePVCNeeded reports whether m's PersistentVolumeClaim currently
+// requests less storage than groupSpec now asks for.
+func (d *Deployment) resizePVCNeeded(m api.MemberStatus, groupSpec api.ServerGroupSpec) bool {
+	wanted := groupSpec.GetResources().Requests[core.ResourceStorage]
+	if wanted.IsZero() || m.PersistentVolumeClaimName == "" {
+		return false
+	}
+	pvc, err := d.GetKubeCli().CoreV1().PersistentVolumeClaims(d.apiObject.GetNamespace()).Get(m.PersistentVolumeClaimName, metav1.GetOptions{})
+	if err != nil {
+		return false
+	}
+	current := pvc.Spec.Resources.Requests[core.ResourceStorage]
+	return current.Cmp(wanted) < 0
+}
+
+// newPlanAction creates a single, freshly identified plan Action.
+func newPlanAction(actionType api.ActionType, group api.ServerGroup, memberID string, params map[string]string) api.Action {
+	return api.Action{
+		ID:       uniuri.New(),
+		Type:     actionType,
+		Group:    group,
+		MemberID: memberID,
+		Params:   params,
+	}
+}
@@ -25,22 +25,86 @@ package deployment
 import (
 	"context"
 	"fmt"
+	"reflect"
+	"time"
 
+	core "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	api "github.com/arangodb/kube-arangodb/pkg/apis/deployment/v1alpha"
+	"github.com/arangodb/kube-arangodb/pkg/deployment/driftdetector"
+	"github.com/arangodb/kube-arangodb/pkg/deployment/livestate"
 	"github.com/rs/zerolog"
 )
 
+// livestatePollInterval is how often the livestate.Store re-polls
+// ArangoDB's cluster health, and the resync period of its Kubernetes
+// informers.
+const livestatePollInterval = 10 * time.Second
+
+// ensureLiveState lazily constructs the livestate.Store and
+// driftdetector.Detector used by executePlan to detect drift once the
+// plan is empty, and starts the Store's informers and health poll on
+// first use. Later calls are a no-op.
+func (d *Deployment) ensureLiveState(ctx context.Context) {
+	if d.driftDetector != nil {
+		return
+	}
+	namespace := d.apiObject.GetNamespace()
+	name := d.apiObject.GetName()
+	store := livestate.NewStore(d.GetKubeCli(), namespace, name, d.GetDatabaseClient, livestatePollInterval)
+	store.Start(ctx)
+	d.driftDetector = driftdetector.NewDetector(namespace, name, store)
+}
+
 // executePlan tries to execute the plan as far as possible.
 // Returns true when it has to be called again soon.
 // False otherwise.
 func (d *Deployment) executePlan(ctx context.Context) (bool, error) {
 	log := d.deps.Log
 
+	d.ensureLiveState(ctx)
+
 	for {
 		if len(d.status.Plan) == 0 {
-			// No plan exists, nothing to be done
+			observePlanLength(d.apiObject.GetNamespace(), d.apiObject.GetName(), 0)
+			if plan := d.createPlan(); len(plan) > 0 {
+				d.status.Plan = plan
+				if err := d.updateCRStatus(); err != nil {
+					log.Debug().Err(err).Msg("Failed to update CR status with new plan")
+					return false, maskAny(err)
+				}
+				continue
+			}
+
+			// No outstanding spec change found. Before the caller considers
+			// this deployment fully reconciled, check whether the live state
+			// has actually drifted from the spec in a way createPlan doesn't
+			// already cover, so it shows up on the CR's status even though
+			// nothing can act on it automatically yet.
+			if d.driftDetector != nil {
+				report := d.driftDetector.Detect(d.apiObject.Spec, d.status)
+				changed := d.status.Drift == nil || !driftReportsEqual(*d.status.Drift, report)
+				d.status.Drift = &report
+				if changed {
+					// Only write when the report itself changed; otherwise every
+					// tick would bump the CR's resourceVersion on LastCheckedAt
+					// alone and retrigger a watch event, looping forever.
+					if err := d.updateCRStatus(); err != nil {
+						log.Debug().Err(err).Msg("Failed to update CR status with drift report")
+						return false, maskAny(err)
+					}
+				}
+				if !report.HasDrift() {
+					log.Debug().Msg("No drift detected, nothing to be done")
+					return false, nil
+				}
+				log.Debug().
+					Int("unexpected-pods", len(report.UnexpectedPods)).
+					Int("missing-members", len(report.MissingMembers)).
+					Int("spec-mismatches", len(report.SpecMismatches)).
+					Msg("Drift detected")
+			}
 			return false, nil
 		}
 
@@ -54,17 +118,25 @@ func (d *Deployment) executePlan(ctx context.Context) (bool, error) {
 			Str("member-id", planAction.MemberID).
 			Logger()
 		action := d.createAction(ctx, log, planAction)
+		observePlanLength(d.apiObject.GetNamespace(), d.apiObject.GetName(), len(d.status.Plan))
 		if planAction.StartTime.IsZero() {
 			// Not started yet
+			observeActionResult(planAction.Type, planActionResultStarted)
+			d.recordActionEvent(core.EventTypeNormal, "ActionStarted", planAction, "")
 			ready, err := action.Start(ctx)
 			if err != nil {
 				log.Debug().Err(err).
 					Msg("Failed to start action")
+				observeActionResult(planAction.Type, planActionResultFailed)
+				d.recordActionEvent(core.EventTypeWarning, "ActionFailed", planAction, err.Error())
 				return false, maskAny(err)
 			}
 			if ready {
 				// Remove action from list
 				d.status.Plan = d.status.Plan[1:]
+				d.recordActionCompletion(planAction)
+				observeActionResult(planAction.Type, planActionResultCompleted)
+				d.recordActionEvent(core.EventTypeNormal, "ActionCompleted", planAction, "")
 			} else {
 				// Mark start time
 				now := metav1.Now()
@@ -83,14 +155,34 @@ func (d *Deployment) executePlan(ctx context.Context) (bool, error) {
 			// Continue with next action
 		} else {
 			// First action of plan has been started, check its progress
+			if elapsed := time.Since(planAction.StartTime.Time); elapsed > maxActionDuration(planAction.Type) {
+				log.Warn().Dur("elapsed", elapsed).Msg("Action timed out, aborting")
+				observeActionResult(planAction.Type, planActionResultTimedOut)
+				observeActionDuration(planAction.Type, elapsed)
+				d.recordActionEvent(core.EventTypeWarning, "ActionFailed", planAction,
+					fmt.Sprintf("Action %s for member %s timed out after %s", planAction.Type, planAction.MemberID, elapsed))
+				d.status.Plan = d.status.Plan[1:]
+				if err := d.updateCRStatus(); err != nil {
+					log.Debug().Err(err).Msg("Failed to update CR status")
+					return false, maskAny(err)
+				}
+				return false, maskAny(fmt.Errorf("action %s for member %s timed out after %s", planAction.Type, planAction.MemberID, elapsed))
+			}
+
 			ready, err := action.CheckProgress(ctx)
 			if err != nil {
 				log.Debug().Err(err).Msg("Failed to check action progress")
+				observeActionResult(planAction.Type, planActionResultFailed)
+				d.recordActionEvent(core.EventTypeWarning, "ActionFailed", planAction, err.Error())
 				return false, maskAny(err)
 			}
 			if ready {
 				// Remove action from list
 				d.status.Plan = d.status.Plan[1:]
+				d.recordActionCompletion(planAction)
+				observeActionResult(planAction.Type, planActionResultCompleted)
+				observeActionDuration(planAction.Type, time.Since(planAction.StartTime.Time))
+				d.recordActionEvent(core.EventTypeNormal, "ActionCompleted", planAction, "")
 				// Save plan update
 				if err := d.updateCRStatus(); err != nil {
 					log.Debug().Err(err).Msg("Failed to update CR status")
@@ -107,25 +199,42 @@ func (d *Deployment) executePlan(ctx context.Context) (bool, error) {
 	}
 }
 
+// recordActionCompletion applies any Status side effect a completed action
+// needs beyond its removal from the Plan.
+func (d *Deployment) recordActionCompletion(action api.Action) {
+	if action.Type == api.ActionTypeRestoreBackup {
+		d.status.RestoredFrom = action.Params["backupName"]
+	}
+}
+
+// recordActionEvent records a Kubernetes Event on the ArangoDeployment
+// object for a single plan action, so `kubectl describe arangodeployment`
+// shows the same information as the operator logs.
+func (d *Deployment) recordActionEvent(eventType, reason string, action api.Action, detail string) {
+	message := fmt.Sprintf("%s for member %s (group %s)", action.Type, action.MemberID, action.Group.AsRole())
+	if detail != "" {
+		message = fmt.Sprintf("%s: %s", message, detail)
+	}
+	d.deps.EventRecorder.Event(d.apiObject, eventType, reason, message)
+}
+
 // startAction performs the start of the given action
 // Returns true if the action is completely finished, false in case
 // the start time needs to be recorded and a ready condition needs to be checked.
 func (d *Deployment) createAction(ctx context.Context, log zerolog.Logger, action api.Action) Action {
 	actionCtx := NewActionContext(log, d)
-	switch action.Type {
-	case api.ActionTypeAddMember:
-		return NewAddMemberAction(log, action, actionCtx)
-	case api.ActionTypeRemoveMember:
-		return NewRemoveMemberAction(log, action, actionCtx)
-	case api.ActionTypeCleanOutMember:
-		return NewCleanOutMemberAction(log, action, actionCtx)
-	case api.ActionTypeShutdownMember:
-		return NewShutdownMemberAction(log, action, actionCtx)
-	case api.ActionTypeRotateMember:
-		return NewRotateMemberAction(log, action, actionCtx)
-	case api.ActionTypeWaitForMemberUp:
-		return NewWaitForMemberUpAction(log, action, actionCtx)
-	default:
+	factory, found := actionFactories[action.Type]
+	if !found {
 		panic(fmt.Sprintf("Unknown action type '%s'", action.Type))
 	}
+	return factory(log, action, actionCtx)
+}
+
+// driftReportsEqual compares two DriftReports ignoring LastCheckedAt, which
+// changes on every detection run regardless of whether anything meaningful
+// about the drift actually changed.
+func driftReportsEqual(a, b driftdetector.DriftReport) bool {
+	a.LastCheckedAt = metav1.Time{}
+	b.LastCheckedAt = metav1.Time{}
+	return reflect.DeepEqual(a, b)
 }
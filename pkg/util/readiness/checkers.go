@@ -0,0 +1,275 @@
+//
+// DISCLAIMER
+//
+// Copyright 2018 ArangoDB GmbH, Cologne, Germany
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Copyright holder is ArangoDB GmbH, Cologne, Germany
+//
+
+package readiness
+
+import (
+	"fmt"
+
+	apps "k8s.io/api/apps/v1"
+	core "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+)
+
+var (
+	podGVK            = schema.GroupVersionKind{Version: "v1", Kind: "Pod"}
+	statefulSetGVK    = schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "StatefulSet"}
+	serviceGVK        = schema.GroupVersionKind{Version: "v1", Kind: "Service"}
+	pvcGVK            = schema.GroupVersionKind{Version: "v1", Kind: "PersistentVolumeClaim"}
+	serviceAccountGVK = schema.GroupVersionKind{Version: "v1", Kind: "ServiceAccount"}
+)
+
+func init() {
+	Register(podGVK, checkPodReady)
+	Register(statefulSetGVK, checkStatefulSetReady)
+	Register(serviceGVK, checkServiceReady)
+	Register(pvcGVK, checkPVCReady)
+	Register(serviceAccountGVK, checkServiceAccountReady)
+}
+
+// checkPodReady requires PodReady & ContainersReady conditions to be true
+// and no container to be stuck in a restart backoff.
+func checkPodReady(obj runtime.Object) (bool, string, error) {
+	pod, ok := obj.(*core.Pod)
+	if !ok {
+		return false, "", fmt.Errorf("expected a Pod, got %T", obj)
+	}
+	var podReady, containersReady bool
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == core.PodReady {
+			if cond.Status != core.ConditionTrue {
+				return false, fmt.Sprintf("condition PodReady is %s", cond.Status), nil
+			}
+			podReady = true
+		}
+		if cond.Type == core.ContainersReady {
+			if cond.Status != core.ConditionTrue {
+				return false, fmt.Sprintf("condition ContainersReady is %s", cond.Status), nil
+			}
+			containersReady = true
+		}
+	}
+	if !podReady || !containersReady {
+		// Conditions not reported yet (e.g. a freshly created Pod that
+		// hasn't been scheduled), as opposed to reported and non-True,
+		// which is handled above. Either way it isn't ready yet.
+		return false, "condition PodReady or ContainersReady not yet reported", nil
+	}
+	for _, cs := range pod.Status.ContainerStatuses {
+		if cs.RestartCount > 0 && cs.State.Waiting != nil && cs.State.Waiting.Reason == "CrashLoopBackOff" {
+			return false, fmt.Sprintf("container %s is in CrashLoopBackOff", cs.Name), nil
+		}
+	}
+	return true, "", nil
+}
+
+// checkStatefulSetReady requires all replicas to be ready and the current
+// revision to match the update revision (i.e. no rollout in progress).
+func checkStatefulSetReady(obj runtime.Object) (bool, string, error) {
+	sts, ok := obj.(*apps.StatefulSet)
+	if !ok {
+		return false, "", fmt.Errorf("expected a StatefulSet, got %T", obj)
+	}
+	wanted := int32(1)
+	if sts.Spec.Replicas != nil {
+		wanted = *sts.Spec.Replicas
+	}
+	if sts.Status.ReadyReplicas != wanted {
+		return false, fmt.Sprintf("readyReplicas %d does not match spec.replicas %d", sts.Status.ReadyReplicas, wanted), nil
+	}
+	if sts.Status.CurrentRevision != sts.Status.UpdateRevision {
+		return false, fmt.Sprintf("currentRevision %s does not match updateRevision %s", sts.Status.CurrentRevision, sts.Status.UpdateRevision), nil
+	}
+	return true, "", nil
+}
+
+// serviceWithEndpoints bundles a Service together with its Endpoints, so
+// checkServiceReady can inspect both without needing API access of its own.
+// Endpoints is nil when the Service has none yet.
+type serviceWithEndpoints struct {
+	*core.Service
+	Endpoints *core.Endpoints
+}
+
+// checkServiceReady requires a ClusterIP service to have at least one ready
+// endpoint address. Headless/ExternalName services are always considered
+// ready, since they have no endpoints to wait for.
+func checkServiceReady(obj runtime.Object) (bool, string, error) {
+	swe, ok := obj.(*serviceWithEndpoints)
+	if !ok {
+		return false, "", fmt.Errorf("expected a Service, got %T", obj)
+	}
+	svc := swe.Service
+	if svc.Spec.Type == core.ServiceTypeExternalName || svc.Spec.ClusterIP == core.ClusterIPNone {
+		return true, "", nil
+	}
+	if svc.Spec.ClusterIP == "" {
+		return false, "ClusterIP not yet assigned", nil
+	}
+	if !hasReadyEndpointAddress(swe.Endpoints) {
+		return false, "no ready endpoint addresses", nil
+	}
+	return true, "", nil
+}
+
+// hasReadyEndpointAddress reports whether endpoints (which may be nil) lists
+// at least one ready address in any of its subsets.
+func hasReadyEndpointAddress(endpoints *core.Endpoints) bool {
+	if endpoints == nil {
+		return false
+	}
+	for _, subset := range endpoints.Subsets {
+		if len(subset.Addresses) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// checkPVCReady requires the PersistentVolumeClaim to be Bound.
+func checkPVCReady(obj runtime.Object) (bool, string, error) {
+	pvc, ok := obj.(*core.PersistentVolumeClaim)
+	if !ok {
+		return false, "", fmt.Errorf("expected a PersistentVolumeClaim, got %T", obj)
+	}
+	if pvc.Status.Phase != core.ClaimBound {
+		return false, fmt.Sprintf("phase is %s, want Bound", pvc.Status.Phase), nil
+	}
+	return true, "", nil
+}
+
+// checkServiceAccountReady requires the ServiceAccount to exist; there is no
+// further readiness state to inspect.
+func checkServiceAccountReady(obj runtime.Object) (bool, string, error) {
+	if _, ok := obj.(*core.ServiceAccount); !ok {
+		return false, "", fmt.Errorf("expected a ServiceAccount, got %T", obj)
+	}
+	return true, "", nil
+}
+
+// ownedObject bundles a resource together with the metadata needed to
+// report on it.
+type ownedObject struct {
+	gvk  schema.GroupVersionKind
+	meta metav1.Object
+	obj  runtime.Object
+}
+
+// collectOwned lists the Pods, StatefulSets, Services (with their
+// Endpoints), PersistentVolumeClaims and ServiceAccounts in namespace and
+// keeps the ones transitively owned by root, i.e. anything root owns
+// directly, anything owned by those, and so on (e.g. the Pods owned by a
+// StatefulSet that is itself owned by root). root itself is not included.
+func collectOwned(kubecli kubernetes.Interface, root metav1.Object, namespace string) ([]ownedObject, error) {
+	var all []ownedObject
+
+	pods, err := kubecli.CoreV1().Pods(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	for i := range pods.Items {
+		p := &pods.Items[i]
+		all = append(all, ownedObject{podGVK, p, p})
+	}
+
+	statefulSets, err := kubecli.AppsV1().StatefulSets(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	for i := range statefulSets.Items {
+		s := &statefulSets.Items[i]
+		all = append(all, ownedObject{statefulSetGVK, s, s})
+	}
+
+	services, err := kubecli.CoreV1().Services(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	for i := range services.Items {
+		s := &services.Items[i]
+		endpoints, err := kubecli.CoreV1().Endpoints(namespace).Get(s.Name, metav1.GetOptions{})
+		if err != nil {
+			if !apierrors.IsNotFound(err) {
+				return nil, maskAny(err)
+			}
+			endpoints = nil
+		}
+		swe := &serviceWithEndpoints{Service: s, Endpoints: endpoints}
+		all = append(all, ownedObject{serviceGVK, swe, swe})
+	}
+
+	pvcs, err := kubecli.CoreV1().PersistentVolumeClaims(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	for i := range pvcs.Items {
+		p := &pvcs.Items[i]
+		all = append(all, ownedObject{pvcGVK, p, p})
+	}
+
+	serviceAccounts, err := kubecli.CoreV1().ServiceAccounts(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	for i := range serviceAccounts.Items {
+		sa := &serviceAccounts.Items[i]
+		all = append(all, ownedObject{serviceAccountGVK, sa, sa})
+	}
+
+	return filterTransitivelyOwned(all, root), nil
+}
+
+// filterTransitivelyOwned keeps the objects in all that are owned by root,
+// either directly or through a chain of owner references, by repeatedly
+// expanding the set of known-owned UIDs until a pass finds nothing new.
+func filterTransitivelyOwned(all []ownedObject, root metav1.Object) []ownedObject {
+	ownerUIDs := map[types.UID]bool{root.GetUID(): true}
+	owned := make([]bool, len(all))
+
+	for {
+		changed := false
+		for i, o := range all {
+			if owned[i] {
+				continue
+			}
+			if isOwnedByAny(o.meta, ownerUIDs) {
+				owned[i] = true
+				ownerUIDs[o.meta.GetUID()] = true
+				changed = true
+			}
+		}
+		if !changed {
+			break
+		}
+	}
+
+	var result []ownedObject
+	for i, o := range all {
+		if owned[i] {
+			result = append(result, o)
+		}
+	}
+	return result
+}
@@ -0,0 +1,177 @@
+//
+// DISCLAIMER
+//
+// Copyright 2018 ArangoDB GmbH, Cologne, Germany
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Copyright holder is ArangoDB GmbH, Cologne, Germany
+//
+
+// Package readiness provides a generalized way to decide whether the
+// Kubernetes resources owned by an ArangoDeployment (Pods, StatefulSets,
+// Services, PersistentVolumeClaims and ServiceAccounts) are ready, modeled
+// on the resource-status approach used by Helm 3.5. The ArangoDeployment CR
+// itself is not checked.
+package readiness
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/pkg/errors"
+)
+
+var maskAny = errors.WithStack
+
+// CheckerFunc decides whether a single Kubernetes object is ready.
+// It returns a human readable reason when the object is not (yet) ready.
+type CheckerFunc func(obj runtime.Object) (ready bool, reason string, err error)
+
+// registry holds the built-in and custom checkers, keyed by the GVK of the
+// object they know how to evaluate.
+var registry = make(map[schema.GroupVersionKind]CheckerFunc)
+
+// Register adds (or replaces) the checker used for objects of the given
+// GroupVersionKind. It is typically called from an init() function of the
+// package implementing the checker.
+func Register(gvk schema.GroupVersionKind, checker CheckerFunc) {
+	registry[gvk] = checker
+}
+
+// lookup returns the checker registered for the given GVK, if any.
+func lookup(gvk schema.GroupVersionKind) (CheckerFunc, bool) {
+	checker, found := registry[gvk]
+	return checker, found
+}
+
+// NotReady describes a single object that kept the overall deployment from
+// being considered ready.
+type NotReady struct {
+	GVK       schema.GroupVersionKind
+	Namespace string
+	Name      string
+	Reason    string
+}
+
+func (n NotReady) String() string {
+	return fmt.Sprintf("%s %s/%s not ready: %s", n.GVK.Kind, n.Namespace, n.Name, n.Reason)
+}
+
+// Report is the outcome of a WaitAll call, naming every object that was
+// (still) not ready when the wait finished.
+type Report struct {
+	NotReady []NotReady
+}
+
+// Ready returns true when every checked object reported ready.
+func (r Report) Ready() bool {
+	return len(r.NotReady) == 0
+}
+
+// Options controls the polling behavior of WaitAll.
+type Options struct {
+	// Timeout is the maximum time to wait for all objects to become ready.
+	Timeout time.Duration
+	// PollInterval is the time to wait between two evaluations of the owned
+	// resources. Defaults to 1s when zero.
+	PollInterval time.Duration
+}
+
+// WaitAll polls the owned resources of root (found by walking owner
+// references, starting at the ArangoDeployment itself) until every one of
+// them is reported ready by its registered CheckerFunc, or the timeout
+// passes. The last evaluated Report is always returned, also when the
+// timeout is hit.
+func WaitAll(ctx context.Context, kubecli kubernetes.Interface, root metav1.Object, namespace string, opts Options) (Report, error) {
+	pollInterval := opts.PollInterval
+	if pollInterval <= 0 {
+		pollInterval = time.Second
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	var lastReport Report
+	for {
+		report, err := evaluateOwned(kubecli, root, namespace)
+		if err != nil {
+			return report, maskAny(err)
+		}
+		lastReport = report
+		if report.Ready() {
+			return report, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return lastReport, maskAny(fmt.Errorf("timed out waiting for %d resource(s) to become ready", len(lastReport.NotReady)))
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// evaluateOwned fetches the resources listed by collectOwned, keeps the
+// ones owned (directly or indirectly) by root, and runs the matching
+// checker against each of them. A checker registered for any other kind
+// is never called.
+func evaluateOwned(kubecli kubernetes.Interface, root metav1.Object, namespace string) (Report, error) {
+	var report Report
+
+	objs, err := collectOwned(kubecli, root, namespace)
+	if err != nil {
+		return report, maskAny(err)
+	}
+
+	for _, owned := range objs {
+		checker, found := lookup(owned.gvk)
+		if !found {
+			// No checker registered for this kind, assume it does not
+			// influence readiness.
+			continue
+		}
+		ready, reason, err := checker(owned.obj)
+		if err != nil {
+			return report, maskAny(err)
+		}
+		if !ready {
+			report.NotReady = append(report.NotReady, NotReady{
+				GVK:       owned.gvk,
+				Namespace: owned.meta.GetNamespace(),
+				Name:      owned.meta.GetName(),
+				Reason:    reason,
+			})
+		}
+	}
+
+	return report, nil
+}
+
+// isOwnedByAny returns true when obj lists any of ownerUIDs as one of its
+// OwnerReferences. Used by filterTransitivelyOwned to walk ownership chains
+// UID by UID rather than just the direct owner.
+func isOwnedByAny(obj metav1.Object, ownerUIDs map[types.UID]bool) bool {
+	for _, ref := range obj.GetOwnerReferences() {
+		if ownerUIDs[ref.UID] {
+			return true
+		}
+	}
+	return false
+}
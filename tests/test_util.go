@@ -37,15 +37,18 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	driver "github.com/arangodb/go-driver"
+	backupApi "github.com/arangodb/kube-arangodb/pkg/apis/backup/v1alpha"
 	api "github.com/arangodb/kube-arangodb/pkg/apis/deployment/v1alpha"
 	"github.com/arangodb/kube-arangodb/pkg/generated/clientset/versioned"
 	"github.com/arangodb/kube-arangodb/pkg/util/arangod"
 	"github.com/arangodb/kube-arangodb/pkg/util/k8sutil"
+	"github.com/arangodb/kube-arangodb/pkg/util/readiness"
 	"github.com/arangodb/kube-arangodb/pkg/util/retry"
 )
 
 const (
 	deploymentReadyTimeout = time.Minute * 2
+	resourceReadyTimeout   = time.Minute * 2
 )
 
 var (
@@ -138,6 +141,75 @@ func waitUntilDeployment(cli versioned.Interface, deploymentName, ns string, pre
 	return result, nil
 }
 
+// waitUntilBackupReady waits until an ArangoBackup with given name in given
+// namespace reached a state where the given predicate returns true. It
+// mirrors waitUntilDeployment for the ArangoBackup resource.
+func waitUntilBackupReady(cli versioned.Interface, backupName, ns string, predicate func(*backupApi.ArangoBackup) error) (*backupApi.ArangoBackup, error) {
+	var result *backupApi.ArangoBackup
+	op := func() error {
+		obj, err := cli.BackupV1alpha().ArangoBackups(ns).Get(backupName, metav1.GetOptions{})
+		if err != nil {
+			result = nil
+			return maskAny(err)
+		}
+		result = obj
+		if predicate != nil {
+			if err := predicate(obj); err != nil {
+				return maskAny(err)
+			}
+		}
+		return nil
+	}
+	if err := retry.Retry(op, deploymentReadyTimeout); err != nil {
+		return nil, maskAny(err)
+	}
+	return result, nil
+}
+
+// backupAvailable is a predicate for waitUntilBackupReady that succeeds
+// once the backup reports its Available condition as true.
+func backupAvailable(b *backupApi.ArangoBackup) error {
+	for _, cond := range b.Status.Conditions {
+		if cond.Type == backupApi.ArangoBackupConditionAvailable && cond.Status == metav1.ConditionTrue {
+			return nil
+		}
+	}
+	return maskAny(fmt.Errorf("Backup %s not yet available", b.GetName()))
+}
+
+// waitUntilActionEvent waits until a Kubernetes Event exists on the given
+// ArangoDeployment recording that a plan action of actionType reached
+// outcome (e.g. "ActionCompleted" or "ActionFailed"), as emitted by
+// executePlan.
+func waitUntilActionEvent(cli kubernetes.Interface, deployment *api.ArangoDeployment, actionType api.ActionType, outcome string, timeout time.Duration) (*v1.Event, error) {
+	var result *v1.Event
+	op := func() error {
+		events, err := cli.CoreV1().Events(deployment.GetNamespace()).List(metav1.ListOptions{})
+		if err != nil {
+			return maskAny(err)
+		}
+		for i := range events.Items {
+			event := &events.Items[i]
+			if event.InvolvedObject.Name != deployment.GetName() {
+				continue
+			}
+			if event.Reason != outcome {
+				continue
+			}
+			if !strings.Contains(event.Message, string(actionType)) {
+				continue
+			}
+			result = event
+			return nil
+		}
+		return maskAny(fmt.Errorf("No %s event found for action %s on deployment %s", outcome, actionType, deployment.GetName()))
+	}
+	if err := retry.Retry(op, timeout); err != nil {
+		return nil, maskAny(err)
+	}
+	return result, nil
+}
+
 // waitUntilSecret waits until a secret with given name in given namespace
 // reached a state where the given predicate returns true.
 func waitUntilSecret(cli kubernetes.Interface, secretName, ns string, predicate func(*v1.Secret) error, timeout time.Duration) (*v1.Secret, error) {
@@ -325,6 +397,19 @@ func removeSecret(cli kubernetes.Interface, secretName, ns string) error {
 }
 
 func waitUntilArangoDeploymentHealthy(deployment *api.ArangoDeployment, DBClient driver.Client, k8sClient kubernetes.Interface) error {
+	// First make sure every Kubernetes resource owned by the deployment
+	// (Pods, StatefulSets, Services, PVCs, ServiceAccounts) reports ready,
+	// before even looking at ArangoDB's own view of cluster health.
+	report, err := readiness.WaitAll(context.Background(), k8sClient, deployment, deployment.GetNamespace(), readiness.Options{
+		Timeout: resourceReadyTimeout,
+	})
+	if err != nil {
+		return maskAny(fmt.Errorf("Resources not ready in time: %v", err))
+	}
+	if !report.Ready() {
+		return maskAny(fmt.Errorf("Resources not ready: %v", report.NotReady))
+	}
+
 	// deployment checks
 	switch mode := deployment.Spec.GetMode(); mode {
 	case api.DeploymentModeCluster: